@@ -0,0 +1,298 @@
+package sqltocsv
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects the codec WriteCompressed pipes CSV output through.
+type Compression string
+
+// Supported Compression values.
+const (
+	CompressionNone   Compression = ""
+	CompressionGzip   Compression = "gzip"
+	CompressionZstd   Compression = "zstd"
+	CompressionSnappy Compression = "snappy"
+)
+
+// WriteCompressed writes rows to path using New(rows)'s default Converter
+// settings. See Converter.WriteCompressed for details.
+func WriteCompressed(path string, rows *sql.Rows) error {
+	return New(rows).WriteCompressed(path)
+}
+
+// WriteCompressed writes the CSV output to path, piping it through the
+// codec named by c.Compression and splitting it into successive numbered
+// parts (e.g. path "out.csv" becomes "out-00001.csv.gz", "out-00002.csv.gz",
+// ...) whenever c.RowsPerFile rows or c.BytesPerFile bytes have been written
+// to the current part, whichever comes first. Headers are re-emitted at the
+// top of every part when c.WriteHeaders is true.
+//
+// If neither RowsPerFile nor BytesPerFile is set, everything is written to
+// a single part.
+//
+// Unlike Write, WriteCompressed does not consult c.Concurrency; rows are
+// always formatted sequentially.
+func (c *Converter) WriteCompressed(path string) error {
+	rows := c.rows
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(c.Headers) > 0 {
+		columnNames = c.Headers
+	}
+
+	part, err := newPartWriter(path, c, columnNames)
+	if err != nil {
+		return err
+	}
+
+	columnLength := len(columnNames)
+	values := make([]interface{}, columnLength)
+	valuePtrs := make([]interface{}, columnLength)
+	columnTypes := c.columnTypeNames(columnNames)
+
+	var rowIndex int64
+	for rows.Next() {
+		for i := range columnNames {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			rowErr := &Error{RowIndex: rowIndex, Err: err}
+			if c.OnRowError != nil && c.OnRowError(rowErr) {
+				rowIndex++
+				continue
+			}
+			part.Close()
+			return rowErr
+		}
+
+		row := make([]string, columnLength)
+		skipRow := false
+		for i := range columnNames {
+			value, err := c.formatValue(columnNames[i], values[i])
+			if err != nil {
+				rowErr := &Error{RowIndex: rowIndex, ColumnName: columnNames[i], ColumnType: columnTypes[i], Err: err}
+				if c.OnRowError != nil && c.OnRowError(rowErr) {
+					skipRow = true
+					break
+				}
+				part.Close()
+				return rowErr
+			}
+			row[i] = value
+		}
+		if skipRow {
+			rowIndex++
+			continue
+		}
+
+		writeRow := true
+		if c.rowPreProcessor != nil {
+			writeRow, row = c.rowPreProcessor(row, columnNames)
+		}
+		if !writeRow {
+			rowIndex++
+			continue
+		}
+
+		if err := part.writeRow(row); err != nil {
+			part.Close()
+			return &Error{RowIndex: rowIndex, Err: err}
+		}
+		rowIndex++
+	}
+
+	if err := rows.Err(); err != nil {
+		part.Close()
+		return &Error{RowIndex: rowIndex, Err: err}
+	}
+	return part.Close()
+}
+
+// partWriter owns the file/compressor/csv stack for the current output part
+// and rolls over to a new numbered part once a configured row or byte
+// threshold is crossed.
+type partWriter struct {
+	c           *Converter
+	path        string
+	columnNames []string
+
+	part       int
+	rowCount   int64
+	file       *os.File
+	counter    *countingWriter
+	compressor io.WriteCloser
+	csv        dialectWriter
+}
+
+func newPartWriter(path string, c *Converter, columnNames []string) (*partWriter, error) {
+	p := &partWriter{c: c, path: path, columnNames: columnNames}
+	if err := p.openNext(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *partWriter) openNext() error {
+	p.part++
+
+	f, err := os.Create(partFileName(p.path, p.part, p.c.Compression))
+	if err != nil {
+		return err
+	}
+	p.file = f
+	p.counter = &countingWriter{w: f}
+
+	var w io.Writer = p.counter
+	switch p.c.Compression {
+	case CompressionNone:
+		p.compressor = nil
+	case CompressionGzip:
+		gz := gzip.NewWriter(w)
+		p.compressor, w = gz, gz
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		p.compressor, w = zw, zw
+	case CompressionSnappy:
+		sw := snappy.NewBufferedWriter(w)
+		p.compressor, w = sw, sw
+	default:
+		return fmt.Errorf("sqltocsv: unknown compression %q", p.c.Compression)
+	}
+
+	if p.c.usesStandardDialect() {
+		cw := csv.NewWriter(w)
+		cw.Comma = p.c.Comma
+		p.csv = stdlibWriter{cw}
+	} else {
+		p.csv = newCustomWriter(w, p.c)
+	}
+
+	p.rowCount = 0
+
+	if p.c.WriteHeaders {
+		if err := p.csv.Write(p.columnNames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *partWriter) writeRow(row []string) error {
+	if err := p.csv.Write(row); err != nil {
+		return err
+	}
+	p.rowCount++
+
+	// p.counter only sees bytes once they clear every buffering layer in
+	// front of it (encoding/csv's bufio.Writer, and gzip/zstd/snappy's own
+	// internal buffers when compressing), so p.counter.n would otherwise
+	// stay near zero until Close far overshot BytesPerFile. Push this row
+	// through those buffers now so shouldRoll sees an up to date count.
+	if p.c.BytesPerFile > 0 {
+		if err := p.flushForByteCount(); err != nil {
+			return err
+		}
+	}
+
+	if p.shouldRoll() {
+		if err := p.closeCurrent(); err != nil {
+			return err
+		}
+		return p.openNext()
+	}
+	return nil
+}
+
+// flushForByteCount flushes the csv writer and, when compression is in use,
+// the compressor too, so every byte this row produced has reached
+// p.counter before shouldRoll checks it.
+func (p *partWriter) flushForByteCount() error {
+	p.csv.Flush()
+	if err := p.csv.Error(); err != nil {
+		return err
+	}
+	if f, ok := p.compressor.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (p *partWriter) shouldRoll() bool {
+	if p.c.RowsPerFile > 0 && p.rowCount >= p.c.RowsPerFile {
+		return true
+	}
+	if p.c.BytesPerFile > 0 && p.counter.n >= p.c.BytesPerFile {
+		return true
+	}
+	return false
+}
+
+func (p *partWriter) closeCurrent() error {
+	p.csv.Flush()
+	if err := p.csv.Error(); err != nil {
+		return err
+	}
+	if p.compressor != nil {
+		if err := p.compressor.Close(); err != nil {
+			return err
+		}
+	}
+	return p.file.Close()
+}
+
+func (p *partWriter) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	err := p.closeCurrent()
+	p.file = nil
+	return err
+}
+
+// partFileName builds the numbered, codec-suffixed name for part n of path,
+// e.g. partFileName("out.csv", 1, CompressionGzip) == "out-00001.csv.gz".
+func partFileName(path string, part int, compression Compression) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	name := fmt.Sprintf("%s-%05d%s", base, part, ext)
+
+	switch compression {
+	case CompressionGzip:
+		name += ".gz"
+	case CompressionZstd:
+		name += ".zst"
+	case CompressionSnappy:
+		name += ".snappy"
+	}
+	return name
+}
+
+// countingWriter tracks the number of bytes written so far, so partWriter
+// can roll over once BytesPerFile is crossed.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,144 @@
+package sqltocsv_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/joho/sqltocsv"
+)
+
+// TestWriteCompressedRowsPerFile covers the RowsPerFile rollover trigger:
+// one row from setupDatabase plus 5 more, split 4 rows per part, should
+// produce a full 4-row part followed by a partial 2-row one, each with its
+// own header.
+func TestWriteCompressedRowsPerFile(t *testing.T) {
+	db := setupDatabase(t)
+	for i := 0; i < 5; i++ {
+		exec(t, db, "INSERT|people|name=Alice,age=?,bdate=?,nickname=?", i, time.Unix(123456789, 0), nil)
+	}
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+
+	converter := sqltocsv.New(rows)
+	converter.RowsPerFile = 4
+	if err := converter.WriteCompressed(path); err != nil {
+		t.Fatalf("error in WriteCompressed: %v", err)
+	}
+
+	parts := readCSVParts(t, dir, nil)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %v", len(parts), parts)
+	}
+	for i, part := range parts {
+		if part[0] != "name,age,bdate" {
+			t.Errorf("part %d: expected a header line, got %q", i, part[0])
+		}
+	}
+	if got := len(parts[0]) - 1; got != 4 {
+		t.Errorf("expected the first part to hold 4 data rows, got %d", got)
+	}
+	if got := len(parts[1]) - 1; got != 2 {
+		t.Errorf("expected the second part to hold 2 data rows, got %d", got)
+	}
+}
+
+// TestWriteCompressedBytesPerFileWithGzip covers the BytesPerFile rollover
+// trigger with gzip compression: a bug here previously let gzip's internal
+// buffering hide every row from the byte counter until Close, so a small
+// BytesPerFile never actually split the output.
+func TestWriteCompressedBytesPerFileWithGzip(t *testing.T) {
+	db := setupDatabase(t)
+	for i := 0; i < 49; i++ {
+		exec(t, db, "INSERT|people|name=Alice,age=?,bdate=?,nickname=?", i, time.Unix(123456789, 0), nil)
+	}
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+
+	converter := sqltocsv.New(rows)
+	converter.Compression = sqltocsv.CompressionGzip
+	converter.BytesPerFile = 200
+	if err := converter.WriteCompressed(path); err != nil {
+		t.Fatalf("error in WriteCompressed: %v", err)
+	}
+
+	parts := readCSVParts(t, dir, func(r *os.File) (*bufio.Scanner, error) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return bufio.NewScanner(gz), nil
+	})
+	if len(parts) < 2 {
+		t.Fatalf("expected BytesPerFile to split output into more than 1 part, got %d", len(parts))
+	}
+	if got := totalDataRows(parts); got != 50 {
+		t.Errorf("expected 50 total data rows across all parts, got %d", got)
+	}
+}
+
+// readCSVParts globs dir for the numbered parts WriteCompressed produces,
+// in order, and reads each back line by line via open (plain text when nil).
+func readCSVParts(t *testing.T, dir string, open func(*os.File) (*bufio.Scanner, error)) [][]string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "out-*"))
+	if err != nil {
+		t.Fatalf("error globbing parts: %v", err)
+	}
+	sort.Strings(matches)
+
+	var parts [][]string
+	for _, name := range matches {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("error opening %v: %v", name, err)
+		}
+		defer f.Close()
+
+		var scanner *bufio.Scanner
+		if open != nil {
+			scanner, err = open(f)
+			if err != nil {
+				t.Fatalf("error decompressing %v: %v", name, err)
+			}
+		} else {
+			scanner = bufio.NewScanner(f)
+		}
+
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("error reading %v: %v", name, err)
+		}
+		parts = append(parts, lines)
+	}
+	return parts
+}
+
+// totalDataRows sums every part's lines after its header.
+func totalDataRows(parts [][]string) int {
+	total := 0
+	for _, part := range parts {
+		if len(part) > 0 {
+			total += len(part) - 1
+		}
+	}
+	return total
+}
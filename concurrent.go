@@ -0,0 +1,164 @@
+package sqltocsv
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// concurrentJob is one scanned-but-not-yet-formatted row, tagged with its
+// position in the result set so the writer can restore row order.
+type concurrentJob struct {
+	seq    int64
+	values []interface{}
+}
+
+// concurrentResult is a formatted row (or a row the RowPreProcessor asked
+// to omit), still tagged with its original position.
+type concurrentResult struct {
+	seq   int64
+	row   []string
+	write bool
+}
+
+// writeConcurrent is the Concurrency > 1 path for the row loop: a single
+// goroutine still owns rows.Next()/Scan() (sql.Rows is not safe for
+// concurrent use), c.Concurrency worker goroutines format rows into CSV
+// records in parallel, and a dedicated writer goroutine reassembles results
+// in original row order before handing them to w. RowPreProcessor semantics are
+// unchanged other than running on a worker goroutine instead of the
+// caller's.
+//
+// Memory/latency tradeoff: workers may run up to ChannelBufferSize rows
+// ahead of the writer, and a result that finishes out of order waits in a
+// reorder buffer until every earlier row has been written, so peak memory
+// scales with the channel buffer and with how unevenly formatting work is
+// distributed across rows. For cheap, uniform formatting this bookkeeping
+// costs more than it saves; it pays off once per-row work (a slow
+// RowPreProcessor, heavy TimeFormat parsing, wide rows) dominates scan time.
+func (c *Converter) writeConcurrent(w dialectWriter, columnNames []string) error {
+	rows := c.rows
+	columnLength := len(columnNames)
+	columnTypes := c.columnTypeNames(columnNames)
+
+	bufSize := c.ChannelBufferSize
+	if bufSize <= 0 {
+		bufSize = c.Concurrency
+	}
+
+	jobs := make(chan concurrentJob, bufSize)
+	results := make(chan concurrentResult, bufSize)
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		errVal  atomic.Pointer[error]
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() { errVal.Store(&err) })
+	}
+	getErr := func() error {
+		if p := errVal.Load(); p != nil {
+			return *p
+		}
+		return nil
+	}
+
+	for i := 0; i < c.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				row := make([]string, columnLength)
+				write := true
+
+				for i := range columnNames {
+					value, err := c.formatValue(columnNames[i], job.values[i])
+					if err != nil {
+						rowErr := &Error{RowIndex: job.seq, ColumnName: columnNames[i], ColumnType: columnTypes[i], Err: err}
+						if c.OnRowError == nil || !c.OnRowError(rowErr) {
+							setErr(rowErr)
+						}
+						write = false
+						break
+					}
+					row[i] = value
+				}
+
+				if write && c.rowPreProcessor != nil {
+					write, row = c.rowPreProcessor(row, columnNames)
+				}
+
+				results <- concurrentResult{seq: job.seq, row: row, write: write}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+
+		pending := make(map[int64]concurrentResult)
+		var next int64
+
+		for res := range results {
+			pending[res.seq] = res
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+
+				if !r.write {
+					continue
+				}
+				if err := w.Write(r.row); err != nil {
+					setErr(&Error{RowIndex: r.seq, Err: err})
+				}
+			}
+		}
+	}()
+
+	var seq int64
+	for rows.Next() {
+		if getErr() != nil {
+			break
+		}
+
+		values := make([]interface{}, columnLength)
+		valuePtrs := make([]interface{}, columnLength)
+		for i := range columnNames {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			rowErr := &Error{RowIndex: seq, Err: err}
+			if c.OnRowError != nil && c.OnRowError(rowErr) {
+				// Nothing will ever format this row, but the writer is
+				// still waiting for its seq before it can flush anything
+				// after it; tell it to move on.
+				results <- concurrentResult{seq: seq, write: false}
+				seq++
+				continue
+			}
+			setErr(rowErr)
+			break
+		}
+
+		jobs <- concurrentJob{seq: seq, values: values}
+		seq++
+	}
+	if err := rows.Err(); err != nil {
+		setErr(&Error{RowIndex: seq, Err: err})
+	}
+	close(jobs)
+	<-writerDone
+
+	return getErr()
+}
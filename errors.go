@@ -0,0 +1,46 @@
+package sqltocsv
+
+import "fmt"
+
+// Error wraps a failure that happened while scanning, formatting or writing
+// a single row, with enough context to point at what went wrong: the
+// (zero-based) index of the row being processed and, when the failure was
+// column-specific, that column's name and database type.
+type Error struct {
+	RowIndex   int64
+	ColumnName string
+	ColumnType string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.ColumnName != "" {
+		return fmt.Sprintf("sqltocsv: row %d, column %q (%s): %v", e.RowIndex, e.ColumnName, e.ColumnType, e.Err)
+	}
+	return fmt.Sprintf("sqltocsv: row %d: %v", e.RowIndex, e.Err)
+}
+
+// Unwrap exposes the underlying cause so errors.Is/errors.As work with the
+// wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// columnTypeNames returns each column's driver-reported database type name,
+// positionally matching columnNames, or a slice of empty strings if the
+// driver doesn't support ColumnTypes.
+func (c *Converter) columnTypeNames(columnNames []string) []string {
+	types := make([]string, len(columnNames))
+
+	colTypes, err := c.rows.ColumnTypes()
+	if err != nil {
+		return types
+	}
+	for i, ct := range colTypes {
+		if i >= len(types) {
+			break
+		}
+		types[i] = ct.DatabaseTypeName()
+	}
+	return types
+}
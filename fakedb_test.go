@@ -0,0 +1,229 @@
+package sqltocsv_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file vendors a minimal fake database/sql driver for the test suite,
+// registered as "test". It understands the small fixture DSL the tests
+// below are written against: "WIPE" clears every table, "CREATE|table|
+// col=type,..." defines one, "INSERT|table|col=val,..." adds a row ("?"
+// placeholders are bound positionally from Exec's args), "FAILAFTER|table|n"
+// makes a later SELECT against that table fail mid-iteration after n rows
+// (simulating a dropped connection), and "SELECT|table|col,col,...|"
+// projects columns back out. There's no real SQL parsing; it's just enough
+// of a driver.Conn/driver.Rows implementation to drive Converter against
+// something that satisfies database/sql.
+func init() {
+	sql.Register("test", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{tables: make(map[string]*fakeTable)}, nil
+}
+
+type fakeColumn struct {
+	name string
+	typ  string
+}
+
+type fakeTable struct {
+	columns   []fakeColumn
+	rows      [][]driver.Value
+	failAfter int // 0 means disabled; see "FAILAFTER|table|n"
+}
+
+func (t *fakeTable) columnIndex(name string) int {
+	for i, c := range t.columns {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+type fakeConn struct {
+	tables map[string]*fakeTable
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("fakedb: transactions not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.exec(s.query, args)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.query(s.query)
+}
+
+// exec implements the "WIPE", "CREATE|table|col=type,..." and
+// "INSERT|table|col=val,..." statements.
+func (c *fakeConn) exec(query string, args []driver.Value) (driver.Result, error) {
+	switch {
+	case query == "WIPE":
+		c.tables = make(map[string]*fakeTable)
+		return driver.ResultNoRows, nil
+
+	case strings.HasPrefix(query, "CREATE|"):
+		parts := strings.SplitN(fakeTrim(query), "|", 3)
+		table := &fakeTable{}
+		if len(parts) == 3 && parts[2] != "" {
+			for _, spec := range strings.Split(parts[2], ",") {
+				kv := strings.SplitN(spec, "=", 2)
+				table.columns = append(table.columns, fakeColumn{name: kv[0], typ: kv[1]})
+			}
+		}
+		c.tables[parts[1]] = table
+		return driver.ResultNoRows, nil
+
+	case strings.HasPrefix(query, "INSERT|"):
+		parts := strings.SplitN(fakeTrim(query), "|", 3)
+		table, ok := c.tables[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("fakedb: no such table %q", parts[1])
+		}
+
+		row := make([]driver.Value, len(table.columns))
+		argIndex := 0
+		for _, assignment := range strings.Split(parts[2], ",") {
+			kv := strings.SplitN(assignment, "=", 2)
+			i := table.columnIndex(kv[0])
+			if i < 0 {
+				return nil, fmt.Errorf("fakedb: no such column %q", kv[0])
+			}
+			if kv[1] == "?" {
+				row[i] = args[argIndex]
+				argIndex++
+			} else {
+				row[i] = kv[1]
+			}
+		}
+		table.rows = append(table.rows, row)
+		return driver.ResultNoRows, nil
+
+	case strings.HasPrefix(query, "FAILAFTER|"):
+		parts := strings.SplitN(query, "|", 3)
+		table, ok := c.tables[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("fakedb: no such table %q", parts[1])
+		}
+		n, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("fakedb: bad FAILAFTER count %q: %v", parts[2], err)
+		}
+		table.failAfter = n
+		return driver.ResultNoRows, nil
+	}
+
+	return nil, fmt.Errorf("fakedb: unsupported exec query %q", query)
+}
+
+// query implements "SELECT|table|col,col,...|".
+func (c *fakeConn) query(query string) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT|") {
+		return nil, fmt.Errorf("fakedb: unsupported query %q", query)
+	}
+
+	parts := strings.SplitN(fakeTrim(query), "|", 3)
+	table, ok := c.tables[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: no such table %q", parts[1])
+	}
+
+	columnNames := strings.Split(parts[2], ",")
+	columnIndexes := make([]int, len(columnNames))
+	columnTypes := make([]string, len(columnNames))
+	for i, name := range columnNames {
+		ci := table.columnIndex(name)
+		if ci < 0 {
+			return nil, fmt.Errorf("fakedb: no such column %q", name)
+		}
+		columnIndexes[i] = ci
+		columnTypes[i] = table.columns[ci].typ
+	}
+
+	rows := make([][]driver.Value, len(table.rows))
+	for i, row := range table.rows {
+		projected := make([]driver.Value, len(columnIndexes))
+		for j, ci := range columnIndexes {
+			projected[j] = row[ci]
+		}
+		rows[i] = projected
+	}
+
+	return &fakeRows{columnNames: columnNames, columnTypes: columnTypes, rows: rows, failAfter: table.failAfter}, nil
+}
+
+// fakeTrim strips a trailing "|" from query strings like
+// "SELECT|people|name,age|", so the final segment splits cleanly.
+func fakeTrim(query string) string {
+	return strings.TrimSuffix(query, "|")
+}
+
+// fakeRows implements driver.Rows over the in-memory rows a query
+// projected, plus the optional RowsColumnTypeScanType and
+// RowsColumnTypeDatabaseTypeName interfaces so Converter sees realistic
+// per-column types instead of the bare interface{} database/sql falls back
+// to for drivers that don't report any.
+type fakeRows struct {
+	columnNames []string
+	columnTypes []string
+	rows        [][]driver.Value
+	pos         int
+	failAfter   int // 0 means disabled
+}
+
+func (r *fakeRows) Columns() []string { return r.columnNames }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.failAfter > 0 && r.pos == r.failAfter {
+		return fmt.Errorf("fakedb: simulated failure after %d rows", r.failAfter)
+	}
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.columnTypes[index]
+}
+
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	switch r.columnTypes[index] {
+	case "int32":
+		return reflect.TypeOf(int64(0))
+	case "datetime":
+		return reflect.TypeOf(time.Time{})
+	default: // "string", "nullstring"
+		return reflect.TypeOf("")
+	}
+}
@@ -0,0 +1,144 @@
+package sqltocsv
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// WriteJSON writes rows as JSON to w using New(rows)'s default Converter
+// settings. See Converter.WriteJSON for details.
+func WriteJSON(w io.Writer, rows *sql.Rows) error {
+	return New(rows).WriteJSON(w)
+}
+
+// WriteJSONFile writes rows as JSON to the named file, creating it if it
+// doesn't already exist.
+func WriteJSONFile(jsonFileName string, rows *sql.Rows) error {
+	return New(rows).WriteJSONFile(jsonFileName)
+}
+
+// WriteJSON writes one JSON object per row, keyed by column name, to w: by
+// default a newline-delimited stream, or a single JSON array when
+// c.JSONArray is true. Unlike Write, WriteJSON does not consult
+// c.Concurrency; rows are always formatted sequentially.
+func (c *Converter) WriteJSON(w io.Writer) error {
+	var sink Sink
+	if c.JSONArray {
+		sink = newJSONArraySink(w)
+	} else {
+		sink = newNDJSONSink(w)
+	}
+
+	if err := c.writeSink(sink); err != nil {
+		return err
+	}
+	return sink.Close()
+}
+
+// WriteJSONFile writes the JSON to the file name given, creating it if it
+// doesn't already exist.
+func (c *Converter) WriteJSONFile(jsonFileName string) error {
+	f, err := os.Create(jsonFileName)
+	if err != nil {
+		return err
+	}
+
+	err = c.WriteJSON(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ndjsonSink writes one compact JSON object per row, newline-delimited.
+type ndjsonSink struct {
+	columnNames []string
+	enc         *json.Encoder
+}
+
+func newNDJSONSink(w io.Writer) *ndjsonSink {
+	return &ndjsonSink{enc: json.NewEncoder(w)}
+}
+
+func (s *ndjsonSink) Init(columnNames []string) error {
+	s.columnNames = columnNames
+	return nil
+}
+
+// WriteHeaders is a no-op: NDJSON has no separate header record, every
+// object is already keyed by column name.
+func (s *ndjsonSink) WriteHeaders(columnNames []string) error {
+	return nil
+}
+
+func (s *ndjsonSink) WriteRow(row []string, rawValues []driver.Value) error {
+	return s.enc.Encode(jsonRecord(s.columnNames, rawValues))
+}
+
+func (s *ndjsonSink) Close() error {
+	return nil
+}
+
+// jsonArraySink writes a single JSON array of row objects.
+type jsonArraySink struct {
+	w           io.Writer
+	columnNames []string
+	wroteFirst  bool
+}
+
+func newJSONArraySink(w io.Writer) *jsonArraySink {
+	return &jsonArraySink{w: w}
+}
+
+func (s *jsonArraySink) Init(columnNames []string) error {
+	s.columnNames = columnNames
+	_, err := s.w.Write([]byte{'['})
+	return err
+}
+
+// WriteHeaders is a no-op: the array's opening bracket is written by Init,
+// unconditionally, since it's structural rather than an optional header.
+func (s *jsonArraySink) WriteHeaders(columnNames []string) error {
+	return nil
+}
+
+func (s *jsonArraySink) WriteRow(row []string, rawValues []driver.Value) error {
+	encoded, err := json.Marshal(jsonRecord(s.columnNames, rawValues))
+	if err != nil {
+		return err
+	}
+
+	if s.wroteFirst {
+		if _, err := s.w.Write([]byte{','}); err != nil {
+			return err
+		}
+	}
+	s.wroteFirst = true
+
+	_, err = s.w.Write(encoded)
+	return err
+}
+
+func (s *jsonArraySink) Close() error {
+	_, err := s.w.Write([]byte{']'})
+	return err
+}
+
+// jsonRecord builds the column-name-keyed object encoding/json renders for
+// a row, converting []byte to a string (encoding/json would otherwise
+// base64-encode it) and passing everything else through as-is so
+// time.Time still marshals via its own MarshalJSON.
+func jsonRecord(columnNames []string, rawValues []driver.Value) map[string]interface{} {
+	record := make(map[string]interface{}, len(columnNames))
+	for i, name := range columnNames {
+		if b, ok := rawValues[i].([]byte); ok {
+			record[name] = string(b)
+		} else {
+			record[name] = rawValues[i]
+		}
+	}
+	return record
+}
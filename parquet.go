@@ -0,0 +1,239 @@
+package sqltocsv
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// WriteParquet writes rows as Parquet to w using New(rows)'s default
+// Converter settings. See Converter.WriteParquet for details.
+func WriteParquet(w io.Writer, rows *sql.Rows) error {
+	return New(rows).WriteParquet(w)
+}
+
+// WriteParquetFile writes rows as Parquet to the named file, creating it if
+// it doesn't already exist.
+func WriteParquetFile(parquetFileName string, rows *sql.Rows) error {
+	return New(rows).WriteParquetFile(parquetFileName)
+}
+
+// WriteParquet writes rows to w as Parquet, inferring each column's
+// physical Parquet type from its sql.ColumnType.ScanType(): integers
+// become INT64, floats DOUBLE, booleans BOOLEAN, and everything else
+// (strings, times, and any type the driver doesn't report a concrete Go
+// type for) a UTF8 field holding the same string Write would produce for
+// that cell (SetColumnFormatter/TimeFormat applied). Every field is
+// OPTIONAL and SQL NULLs are written as Parquet nulls, so NullString and
+// column formatters are not applied to them. Unlike
+// Write, WriteParquet does not consult c.Concurrency; rows are always
+// formatted sequentially (c.Concurrency is still used, as before, to size
+// the Parquet writer's internal parallelism).
+func (c *Converter) WriteParquet(w io.Writer) error {
+	rows := c.rows
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(c.Headers) > 0 {
+		columnNames = c.Headers
+	}
+
+	fields := parquetFields(columnNames, rows)
+
+	pw, err := writer.NewJSONWriter(parquetSchema(fields), writerfile.NewWriterFile(w), int64(maxInt(c.Concurrency, 1)))
+	if err != nil {
+		return err
+	}
+
+	sink := &parquetSink{pw: pw, fields: fields}
+	if err := c.writeSink(sink); err != nil {
+		pw.WriteStop()
+		return err
+	}
+
+	return pw.WriteStop()
+}
+
+// WriteParquetFile writes the Parquet file to the file name given, creating
+// it if it doesn't already exist.
+func (c *Converter) WriteParquetFile(parquetFileName string) error {
+	f, err := os.Create(parquetFileName)
+	if err != nil {
+		return err
+	}
+
+	err = c.WriteParquet(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// parquetKind is the physical Parquet representation parquetFields infers
+// for a column.
+type parquetKind int
+
+const (
+	parquetString parquetKind = iota
+	parquetInt64
+	parquetDouble
+	parquetBool
+)
+
+// parquetField pairs a column name with the parquetKind inferred for it.
+type parquetField struct {
+	name string
+	kind parquetKind
+}
+
+// parquetFields inspects rows.ColumnTypes() to decide each column's
+// parquetKind. Columns the driver can't report a concrete ScanType for
+// (including any driver that doesn't implement the optional
+// RowsColumnTypeScanType interface at all, in which case database/sql
+// reports a bare interface{}) fall back to parquetString.
+func parquetFields(columnNames []string, rows *sql.Rows) []parquetField {
+	fields := make([]parquetField, len(columnNames))
+	colTypes, err := rows.ColumnTypes()
+
+	for i, name := range columnNames {
+		kind := parquetString
+		if err == nil && i < len(colTypes) {
+			kind = parquetKindFor(colTypes[i].ScanType())
+		}
+		fields[i] = parquetField{name: name, kind: kind}
+	}
+	return fields
+}
+
+func parquetKindFor(scanType reflect.Type) parquetKind {
+	if scanType == nil {
+		return parquetString
+	}
+
+	switch scanType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return parquetInt64
+	case reflect.Float32, reflect.Float64:
+		return parquetDouble
+	case reflect.Bool:
+		return parquetBool
+	default:
+		return parquetString
+	}
+}
+
+// parquetSchema builds the JSON schema string writer.NewJSONWriter expects,
+// one OPTIONAL field per fields entry typed according to its parquetKind.
+func parquetSchema(fields []parquetField) string {
+	tags := make([]string, len(fields))
+	for i, f := range fields {
+		tags[i] = fmt.Sprintf(`{"Tag": "name=%s, %s, repetitiontype=OPTIONAL"}`, f.name, parquetTypeTag(f.kind))
+	}
+
+	return fmt.Sprintf(
+		`{"Tag": "name=row, repetitiontype=REQUIRED", "Fields": [%s]}`,
+		strings.Join(tags, ", "),
+	)
+}
+
+func parquetTypeTag(kind parquetKind) string {
+	switch kind {
+	case parquetInt64:
+		return "type=INT64"
+	case parquetDouble:
+		return "type=DOUBLE"
+	case parquetBool:
+		return "type=BOOLEAN"
+	default:
+		return "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+}
+
+// parquetSink writes each row as a JSON-encoded object into pw, matching
+// the schema parquetSchema built for the same fields. The schema (and
+// therefore the column names and kinds) is fixed when the Parquet writer
+// is created, so Init/WriteHeaders are no-ops here rather than the source
+// of truth they are for the other sinks.
+type parquetSink struct {
+	pw     *writer.JSONWriter
+	fields []parquetField
+}
+
+func (s *parquetSink) Init(columnNames []string) error {
+	return nil
+}
+
+func (s *parquetSink) WriteHeaders(columnNames []string) error {
+	return nil
+}
+
+func (s *parquetSink) WriteRow(row []string, rawValues []driver.Value) error {
+	record := make(map[string]interface{}, len(s.fields))
+	for i, f := range s.fields {
+		record[f.name] = parquetValue(f.kind, row[i], rawValues[i])
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.pw.Write(string(encoded))
+}
+
+func (s *parquetSink) Close() error {
+	return nil
+}
+
+// parquetValue picks what to marshal for one cell: nil round-trips as a
+// JSON null (every field is OPTIONAL), a raw value matching kind is used
+// as-is so it's encoded as a genuine Parquet number/bool, and anything
+// else (including a kind mismatch) falls back to formatted, the same
+// string the CSV sink would have written for this cell.
+func parquetValue(kind parquetKind, formatted string, raw driver.Value) interface{} {
+	if raw == nil {
+		return nil
+	}
+
+	switch kind {
+	case parquetInt64:
+		switch v := raw.(type) {
+		case int64:
+			return v
+		case int32:
+			return int64(v)
+		case int:
+			return int64(v)
+		}
+	case parquetDouble:
+		switch v := raw.(type) {
+		case float64:
+			return v
+		case float32:
+			return float64(v)
+		}
+	case parquetBool:
+		if v, ok := raw.(bool); ok {
+			return v
+		}
+	}
+
+	return formatted
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
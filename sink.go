@@ -0,0 +1,118 @@
+package sqltocsv
+
+import "database/sql/driver"
+
+// Sink is the output abstraction the write path runs on. CSV (the default,
+// via Write/WriteFile/WriteString) is just one Sink among several: see
+// WriteJSON, WriteXLSX and WriteParquet for the others.
+type Sink interface {
+	// Init is called once, with the column names, before any rows and
+	// regardless of Converter.WriteHeaders. Sinks that need the column
+	// names for something other than a literal header row (NDJSON/JSON
+	// array keying every record by name, the JSON array's opening
+	// bracket) must capture them here, not in WriteHeaders.
+	Init(columnNames []string) error
+	// WriteHeaders is called once, with the column names, before any rows,
+	// but only when Converter.WriteHeaders is true. It exists to emit a
+	// literal header record (a CSV header row, an XLSX header row); it is
+	// not the sink's only chance to see the column names.
+	WriteHeaders(columnNames []string) error
+	// WriteRow is called once per row that survives RowPreProcessor. row
+	// holds the already-formatted (SetColumnFormatter/NullString/
+	// TimeFormat applied) string values; rawValues holds the same columns
+	// as scanned from the driver, for sinks that want to preserve a
+	// native type (e.g. Parquet, XLSX numeric cells) instead of row's
+	// string rendering.
+	WriteRow(row []string, rawValues []driver.Value) error
+	// Close finalises the sink (flushing buffers, writing a trailing
+	// array bracket, etc.) once every row has been written.
+	Close() error
+}
+
+// writeSink drives c.rows through sink: scan, per-column formatting
+// (honouring SetColumnFormatter/NullString/TimeFormat), RowPreProcessor,
+// then sink.WriteRow, wrapping failures in *Error and consulting
+// OnRowError exactly like Write does. Callers are responsible for calling
+// sink.Close() once writeSink returns.
+func (c *Converter) writeSink(sink Sink) error {
+	rows := c.rows
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(c.Headers) > 0 {
+		columnNames = c.Headers
+	}
+
+	if err := sink.Init(columnNames); err != nil {
+		return err
+	}
+	if c.WriteHeaders {
+		if err := sink.WriteHeaders(columnNames); err != nil {
+			return err
+		}
+	}
+
+	columnLength := len(columnNames)
+	values := make([]interface{}, columnLength)
+	valuePtrs := make([]interface{}, columnLength)
+	columnTypes := c.columnTypeNames(columnNames)
+
+	var rowIndex int64
+	for rows.Next() {
+		for i := range columnNames {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			rowErr := &Error{RowIndex: rowIndex, Err: err}
+			if c.OnRowError != nil && c.OnRowError(rowErr) {
+				rowIndex++
+				continue
+			}
+			return rowErr
+		}
+
+		row := make([]string, columnLength)
+		rawValues := make([]driver.Value, columnLength)
+		skipRow := false
+
+		for i := range columnNames {
+			rawValues[i] = values[i]
+
+			value, err := c.formatValue(columnNames[i], values[i])
+			if err != nil {
+				rowErr := &Error{RowIndex: rowIndex, ColumnName: columnNames[i], ColumnType: columnTypes[i], Err: err}
+				if c.OnRowError != nil && c.OnRowError(rowErr) {
+					skipRow = true
+					break
+				}
+				return rowErr
+			}
+			row[i] = value
+		}
+
+		if skipRow {
+			rowIndex++
+			continue
+		}
+
+		writeRow := true
+		if c.rowPreProcessor != nil {
+			writeRow, row = c.rowPreProcessor(row, columnNames)
+		}
+
+		if writeRow {
+			if err := sink.WriteRow(row, rawValues); err != nil {
+				return &Error{RowIndex: rowIndex, Err: err}
+			}
+		}
+		rowIndex++
+	}
+
+	if err := rows.Err(); err != nil {
+		return &Error{RowIndex: rowIndex, Err: err}
+	}
+	return nil
+}
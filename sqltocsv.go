@@ -0,0 +1,382 @@
+// Package sqltocsv converts sql.Rows from a database query into CSV output.
+//
+// Use the top-level Write, WriteFile and WriteString functions for the
+// common case of dumping a *sql.Rows straight to CSV with sensible
+// defaults, or use New to get a Converter that lets you customise
+// headers, time formatting, the CSV dialect and row pre-processing
+// before writing.
+package sqltocsv
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteFile will write a CSV file to the file name specified (with headers)
+// based on whatever is in the sql.Rows you pass in.
+func WriteFile(csvFileName string, rows *sql.Rows) error {
+	return New(rows).WriteFile(csvFileName)
+}
+
+// WriteString will return a string of the CSV representation
+// of the sql.Rows you pass in, or an error if something goes wrong.
+func WriteString(rows *sql.Rows) (string, error) {
+	return New(rows).WriteString()
+}
+
+// Write will write a CSV formatted result set to the writer you provide.
+func Write(writer io.Writer, rows *sql.Rows) error {
+	return New(rows).Write(writer)
+}
+
+// CsvPreProcessorFunc is a function that allows manipulation of the row prior
+// to it being written.
+type CsvPreProcessorFunc func(row []string, columnNames []string) (bool, []string)
+
+// Converter does the actual work of converting the rows to CSV.
+// You should create one via New() and then run WriteFile(), WriteString()
+// or Write().
+type Converter struct {
+	Headers      []string // Column headers to use (default is rows.Columns())
+	WriteHeaders bool     // Flag to output headers in your CSV (default is true)
+	TimeFormat   string   // Layout to use for time.Time values (default uses time.Time's default format)
+
+	// Comma is the field delimiter used when writing records (default ',').
+	// Set it to '\t' for TSV output.
+	Comma rune
+	// Quote is the character used to quote fields that need it (default '"').
+	Quote rune
+	// LineTerminator is written at the end of every record (default "\n").
+	// Set it to "\r\n" for CSVs that Excel and other Windows tools expect.
+	LineTerminator string
+	// AlwaysQuote forces every field to be quoted, even when it doesn't
+	// strictly need it.
+	AlwaysQuote bool
+	// EscapeBackslash switches quoting from the standard CSV convention of
+	// doubling the quote character to escaping it (and embedded newlines
+	// and backslashes) with a leading backslash, as used by MySQL's
+	// LOAD DATA / SELECT ... INTO OUTFILE.
+	EscapeBackslash bool
+	// NullString is written out in place of SQL NULL values (default "").
+	NullString string
+
+	// Compression selects the codec WriteCompressed pipes CSV output
+	// through (default CompressionNone).
+	Compression Compression
+	// RowsPerFile rolls WriteCompressed over to a new numbered part after
+	// this many rows have been written to the current one. Zero means no
+	// row-count based rollover.
+	RowsPerFile int64
+	// BytesPerFile rolls WriteCompressed over to a new numbered part once
+	// the current one has this many bytes written to disk. Zero means no
+	// size based rollover.
+	BytesPerFile int64
+
+	// Concurrency, when greater than 1, formats that many rows in parallel
+	// via a worker pool instead of formatting rows one at a time on the
+	// calling goroutine. rows.Scan is always called sequentially (sql.Rows
+	// is not safe for concurrent use); only the formatValue/RowPreProcessor
+	// work is parallelised, and results are written in original row order.
+	//
+	// Concurrency only applies to Write/WriteFile/WriteString: WriteJSON,
+	// WriteXLSX, WriteParquet and WriteCompressed all format rows
+	// sequentially on the calling goroutine regardless of this setting.
+	Concurrency int
+	// ChannelBufferSize sets the size of the channels used to hand rows to
+	// and results back from the worker pool when Concurrency > 1. Defaults
+	// to Concurrency when left at zero.
+	ChannelBufferSize int
+
+	// JSONArray selects WriteJSON's output format: a single JSON array
+	// (true) or newline-delimited JSON, one object per row (false, the
+	// default — friendlier to stream and to tools like `jq`/`zstd` that
+	// process line-by-line).
+	JSONArray bool
+
+	// OnRowError, when set, is called with an *Error whenever scanning or
+	// formatting a row fails. Returning true skips that row and continues
+	// the export; returning false aborts it, surfacing the same *Error
+	// from Write/WriteFile/WriteCompressed. If Concurrency > 1, OnRowError
+	// may be called concurrently from multiple worker goroutines.
+	OnRowError func(err *Error) (skip bool)
+
+	rows             *sql.Rows
+	rowPreProcessor  CsvPreProcessorFunc
+	columnFormatters map[string]func(driver.Value) (string, error)
+}
+
+// SetColumnFormatter registers fn as the formatter for the named column,
+// overriding the default value-to-string conversion (raw []byte stringified,
+// NullString substituted for NULL, time.Time formatted via TimeFormat) for
+// that column only. Column formatting runs before the RowPreProcessor, so
+// both hooks compose.
+func (c *Converter) SetColumnFormatter(name string, fn func(driver.Value) (string, error)) {
+	if c.columnFormatters == nil {
+		c.columnFormatters = make(map[string]func(driver.Value) (string, error))
+	}
+	c.columnFormatters[name] = fn
+}
+
+// New will return a Converter which will write your CSV however you like, but
+// will allow you to set a bunch of non-default behaviour like overriding
+// headers or injecting a pre-processing step into your conversion.
+func New(rows *sql.Rows) *Converter {
+	return &Converter{
+		rows:           rows,
+		WriteHeaders:   true,
+		Comma:          ',',
+		Quote:          '"',
+		LineTerminator: "\n",
+	}
+}
+
+// SetRowPreProcessor lets you specify a CsvPreProcessorFunc for this
+// conversion.
+func (c *Converter) SetRowPreProcessor(processor CsvPreProcessorFunc) {
+	c.rowPreProcessor = processor
+}
+
+// String returns a string of the CSV. Error is swallowed in this
+// convenience method. Use WriteString if you need the error.
+func (c *Converter) String() string {
+	buffer := bytes.Buffer{}
+	c.Write(&buffer)
+	return buffer.String()
+}
+
+// WriteString returns a string of the CSV.
+func (c *Converter) WriteString() (string, error) {
+	buffer := bytes.Buffer{}
+	err := c.Write(&buffer)
+	return buffer.String(), err
+}
+
+// WriteFile writes the CSV to the file name given, creating it if it doesn't
+// already exist.
+func (c *Converter) WriteFile(csvFileName string) error {
+	f, err := os.Create(csvFileName)
+	if err != nil {
+		return err
+	}
+
+	err = c.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// usesStandardDialect reports whether encoding/csv can be used as-is, or
+// whether the configured dialect needs the custom writer below.
+func (c *Converter) usesStandardDialect() bool {
+	return c.Quote == '"' && c.LineTerminator == "\n" && !c.AlwaysQuote && !c.EscapeBackslash
+}
+
+// Write writes the CSV to the io.Writer given. CSV is just the default
+// Sink; see WriteJSON, WriteXLSX and WriteParquet for the others.
+func (c *Converter) Write(writer io.Writer) error {
+	var w dialectWriter
+	if c.usesStandardDialect() {
+		csvWriter := csv.NewWriter(writer)
+		csvWriter.Comma = c.Comma
+		w = stdlibWriter{csvWriter}
+	} else {
+		w = newCustomWriter(writer, c)
+	}
+
+	if c.Concurrency > 1 {
+		columnNames, err := c.rows.Columns()
+		if err != nil {
+			return err
+		}
+		if len(c.Headers) > 0 {
+			columnNames = c.Headers
+		}
+
+		if c.WriteHeaders {
+			if err := w.Write(columnNames); err != nil {
+				return err
+			}
+		}
+		if err := c.writeConcurrent(w, columnNames); err != nil {
+			return err
+		}
+
+		w.Flush()
+		return w.Error()
+	}
+
+	sink := csvSink{w}
+	if err := c.writeSink(sink); err != nil {
+		return err
+	}
+	return sink.Close()
+}
+
+// csvSink adapts the dialectWriter used by Write/WriteFile/WriteString
+// (encoding/csv, or the custom dialect writer for formats it can't
+// express) to the Sink interface.
+type csvSink struct {
+	w dialectWriter
+}
+
+func (s csvSink) Init(columnNames []string) error {
+	return nil
+}
+
+func (s csvSink) WriteHeaders(columnNames []string) error {
+	return s.w.Write(columnNames)
+}
+
+func (s csvSink) WriteRow(row []string, rawValues []driver.Value) error {
+	return s.w.Write(row)
+}
+
+func (s csvSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// formatValue renders a single scanned column value the way it will appear
+// in the CSV. If a formatter was registered for columnName via
+// SetColumnFormatter, it is used as-is; otherwise the default conversion
+// applies: []byte becomes a string, nil becomes c.NullString, and
+// time.Time is formatted with c.TimeFormat when one is set.
+func (c *Converter) formatValue(columnName string, rawValue interface{}) (string, error) {
+	if fn, ok := c.columnFormatters[columnName]; ok {
+		return fn(rawValue)
+	}
+
+	if rawValue == nil {
+		return c.NullString, nil
+	}
+
+	var value interface{}
+	if byteArray, ok := rawValue.([]byte); ok {
+		value = string(byteArray)
+	} else {
+		value = rawValue
+	}
+
+	if timeValue, ok := value.(time.Time); ok && c.TimeFormat != "" {
+		value = timeValue.Format(c.TimeFormat)
+	}
+
+	return fmt.Sprintf("%v", value), nil
+}
+
+// dialectWriter is the minimal surface Converter.Write needs from a CSV
+// encoder, satisfied either by encoding/csv (the common case) or by
+// customWriter below (for dialects encoding/csv can't express).
+type dialectWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// stdlibWriter adapts *csv.Writer to dialectWriter.
+type stdlibWriter struct {
+	*csv.Writer
+}
+
+// customWriter implements the dialect options encoding/csv doesn't support:
+// a custom quote character, a custom line terminator and backslash-escaping
+// of special characters instead of quote-doubling.
+type customWriter struct {
+	w               io.Writer
+	comma           rune
+	quote           rune
+	lineTerminator  string
+	alwaysQuote     bool
+	escapeBackslash bool
+	err             error
+}
+
+func newCustomWriter(w io.Writer, c *Converter) *customWriter {
+	return &customWriter{
+		w:               w,
+		comma:           c.Comma,
+		quote:           c.Quote,
+		lineTerminator:  c.LineTerminator,
+		alwaysQuote:     c.AlwaysQuote,
+		escapeBackslash: c.EscapeBackslash,
+	}
+}
+
+func (c *customWriter) Write(record []string) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	var line bytes.Buffer
+	for i, field := range record {
+		if i > 0 {
+			line.WriteRune(c.comma)
+		}
+		line.WriteString(c.encodeField(field))
+	}
+	line.WriteString(c.lineTerminator)
+
+	_, err := c.w.Write(line.Bytes())
+	if err != nil {
+		c.err = err
+	}
+	return err
+}
+
+func (c *customWriter) needsQuoting(field string) bool {
+	if c.alwaysQuote {
+		return true
+	}
+	return strings.ContainsRune(field, c.comma) ||
+		strings.ContainsRune(field, c.quote) ||
+		strings.ContainsAny(field, "\n\r\\")
+}
+
+func (c *customWriter) encodeField(field string) string {
+	if !c.needsQuoting(field) {
+		return field
+	}
+
+	var out strings.Builder
+	out.WriteRune(c.quote)
+
+	if c.escapeBackslash {
+		for _, r := range field {
+			switch r {
+			case c.quote, '\\':
+				out.WriteRune('\\')
+				out.WriteRune(r)
+			case '\n':
+				out.WriteString(`\n`)
+			case '\r':
+				out.WriteString(`\r`)
+			default:
+				out.WriteRune(r)
+			}
+		}
+	} else {
+		for _, r := range field {
+			if r == c.quote {
+				out.WriteRune(c.quote)
+			}
+			out.WriteRune(r)
+		}
+	}
+
+	out.WriteRune(c.quote)
+	return out.String()
+}
+
+func (c *customWriter) Flush() {}
+
+func (c *customWriter) Error() error {
+	return c.err
+}
@@ -3,13 +3,19 @@ package sqltocsv_test
 import (
 	"bytes"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/joho/sqltocsv"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
 )
 
 // Fatalf interface for easy testing
@@ -133,6 +139,319 @@ func TestConvertingNilValueShouldReturnEmptyString(t *testing.T) {
 	assertCsvMatch(t, expected, actual)
 }
 
+func TestSetColumnFormatter(t *testing.T) {
+	converter := sqltocsv.New(getTestRowsByQuery(t, "SELECT|people|name,nickname,age|"))
+
+	converter.SetColumnFormatter("nickname", func(value driver.Value) (string, error) {
+		if value == nil {
+			return `\N`, nil
+		}
+		return fmt.Sprintf("%v", value), nil
+	})
+
+	expected := `name,nickname,age` + "\n" + `Alice,\N,1` + "\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+func TestSetColumnFormatterComposesWithRowPreProcessor(t *testing.T) {
+	converter := getConverter(t)
+
+	converter.SetColumnFormatter("age", func(value driver.Value) (string, error) {
+		return fmt.Sprintf("age:%v", value), nil
+	})
+	converter.SetRowPreProcessor(func(row []string, columnNames []string) (bool, []string) {
+		row[0] = strings.ToUpper(row[0])
+		return true, row
+	})
+
+	expected := "name,age,bdate\nALICE,age:1,1973-11-29 21:33:09 +0000 UTC\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+func TestOnRowErrorSkipsRow(t *testing.T) {
+	db := setupDatabase(t)
+	exec(t, db, "INSERT|people|name=Bob,age=?,bdate=?,nickname=?", 2, time.Unix(123456789, 0), nil)
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	converter := sqltocsv.New(rows)
+	converter.SetColumnFormatter("name", func(value driver.Value) (string, error) {
+		if value == "Bob" {
+			return "", fmt.Errorf("boom")
+		}
+		return fmt.Sprintf("%v", value), nil
+	})
+
+	var skipped []*sqltocsv.Error
+	converter.OnRowError = func(err *sqltocsv.Error) bool {
+		skipped = append(skipped, err)
+		return true
+	}
+
+	expected := "name,age,bdate\nAlice,1,1973-11-29 21:33:09 +0000 UTC\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+
+	if len(skipped) != 1 {
+		t.Fatalf("expected 1 skipped row, got %d", len(skipped))
+	}
+	if skipped[0].RowIndex != 1 || skipped[0].ColumnName != "name" {
+		t.Errorf("unexpected *Error: %+v", skipped[0])
+	}
+}
+
+func TestOnRowErrorAbortsWhenNotSkipping(t *testing.T) {
+	db := setupDatabase(t)
+	exec(t, db, "INSERT|people|name=Bob,age=?,bdate=?,nickname=?", 2, time.Unix(123456789, 0), nil)
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	converter := sqltocsv.New(rows)
+	boom := fmt.Errorf("boom")
+	converter.SetColumnFormatter("name", func(value driver.Value) (string, error) {
+		if value == "Bob" {
+			return "", boom
+		}
+		return fmt.Sprintf("%v", value), nil
+	})
+
+	_, err = converter.WriteString()
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	var csvErr *sqltocsv.Error
+	if !errors.As(err, &csvErr) {
+		t.Fatalf("expected *sqltocsv.Error, got %T: %v", err, err)
+	}
+	if csvErr.RowIndex != 1 || csvErr.ColumnName != "name" {
+		t.Errorf("unexpected *Error: %+v", csvErr)
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("expected errors.Is to find the wrapped cause")
+	}
+}
+
+func TestWriteJSONDefaultsToNDJSON(t *testing.T) {
+	converter := getConverter(t)
+
+	buffer := &bytes.Buffer{}
+	if err := converter.WriteJSON(buffer); err != nil {
+		t.Fatalf("error in WriteJSON: %v", err)
+	}
+
+	expected := `{"age":1,"bdate":"1973-11-29T21:33:09Z","name":"Alice"}` + "\n"
+	assertCsvMatch(t, expected, buffer.String())
+}
+
+func TestWriteJSONArray(t *testing.T) {
+	converter := getConverter(t)
+	converter.JSONArray = true
+
+	buffer := &bytes.Buffer{}
+	if err := converter.WriteJSON(buffer); err != nil {
+		t.Fatalf("error in WriteJSON: %v", err)
+	}
+
+	expected := `[{"age":1,"bdate":"1973-11-29T21:33:09Z","name":"Alice"}]`
+	assertCsvMatch(t, expected, buffer.String())
+}
+
+// TestWriteJSONIgnoresWriteHeaders covers a bug where WriteJSON relied on
+// Converter.WriteHeaders to learn the column names at all: with
+// WriteHeaders false, NDJSON records came out empty and the JSON array was
+// missing its opening bracket. JSON has no header record, so WriteHeaders
+// shouldn't change its output at all.
+func TestWriteJSONIgnoresWriteHeaders(t *testing.T) {
+	converter := getConverter(t)
+	converter.WriteHeaders = false
+
+	buffer := &bytes.Buffer{}
+	if err := converter.WriteJSON(buffer); err != nil {
+		t.Fatalf("error in WriteJSON: %v", err)
+	}
+
+	expected := `{"age":1,"bdate":"1973-11-29T21:33:09Z","name":"Alice"}` + "\n"
+	assertCsvMatch(t, expected, buffer.String())
+}
+
+func TestWriteJSONArrayIgnoresWriteHeaders(t *testing.T) {
+	converter := getConverter(t)
+	converter.JSONArray = true
+	converter.WriteHeaders = false
+
+	buffer := &bytes.Buffer{}
+	if err := converter.WriteJSON(buffer); err != nil {
+		t.Fatalf("error in WriteJSON: %v", err)
+	}
+
+	expected := `[{"age":1,"bdate":"1973-11-29T21:33:09Z","name":"Alice"}]`
+	assertCsvMatch(t, expected, buffer.String())
+}
+
+func TestDialectTabSeparated(t *testing.T) {
+	converter := getConverter(t)
+
+	converter.Comma = '\t'
+
+	expected := "name\tage\tbdate\nAlice\t1\t1973-11-29 21:33:09 +0000 UTC\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+func TestDialectPipeSeparatedSingleQuoted(t *testing.T) {
+	converter := sqltocsv.New(getTestRowsByQuery(t, "SELECT|people|name,nickname|"))
+
+	converter.Comma = '|'
+	converter.Quote = '\''
+	converter.AlwaysQuote = true
+
+	expected := "'name'|'nickname'\n'Alice'|''\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+func TestDialectCRLFLineTerminator(t *testing.T) {
+	converter := getConverter(t)
+
+	converter.LineTerminator = "\r\n"
+
+	expected := "name,age,bdate\r\nAlice,1,1973-11-29 21:33:09 +0000 UTC\r\n"
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+func TestConcurrencyPreservesRowOrder(t *testing.T) {
+	db := setupDatabase(t)
+	expected := "name,age,bdate\nAlice,1,1973-11-29 21:33:09 +0000 UTC\n"
+	for i := 0; i < 500; i++ {
+		exec(t, db, "INSERT|people|name=Alice,age=?,bdate=?,nickname=?", i, time.Unix(123456789, 0), nil)
+		expected += fmt.Sprintf("Alice,%d,1973-11-29 21:33:09 +0000 UTC\n", i)
+	}
+
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	converter := sqltocsv.New(rows)
+	converter.Concurrency = 8
+
+	actual := converter.String()
+
+	assertCsvMatch(t, expected, actual)
+}
+
+// TestRowsErrIsSurfaced covers a bug where a mid-iteration scan failure
+// (e.g. a dropped connection) made rows.Next() return false exactly like a
+// clean, complete result set, so it was silently treated as one instead of
+// failing the export.
+func TestRowsErrIsSurfaced(t *testing.T) {
+	db := setupDatabase(t)
+	exec(t, db, "FAILAFTER|people|1")
+
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	_, err = sqltocsv.WriteString(rows)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	var csvErr *sqltocsv.Error
+	if !errors.As(err, &csvErr) {
+		t.Fatalf("expected *sqltocsv.Error, got %T: %v", err, err)
+	}
+}
+
+// TestRowsErrIsSurfacedConcurrent is TestRowsErrIsSurfaced's counterpart for
+// the Concurrency > 1 write path.
+func TestRowsErrIsSurfacedConcurrent(t *testing.T) {
+	db := setupDatabase(t)
+	exec(t, db, "FAILAFTER|people|1")
+
+	rows, err := db.Query("SELECT|people|name,age,bdate|")
+	if err != nil {
+		t.Fatalf("error querying: %v", err)
+	}
+
+	converter := sqltocsv.New(rows)
+	converter.Concurrency = 4
+
+	_, err = converter.WriteString()
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	var csvErr *sqltocsv.Error
+	if !errors.As(err, &csvErr) {
+		t.Fatalf("expected *sqltocsv.Error, got %T: %v", err, err)
+	}
+}
+
+// personParquetRow mirrors the schema WriteParquet should infer for the
+// "people" fixture: age (scanned by the driver as an int64) becomes a
+// genuine INT64 column rather than a string.
+type personParquetRow struct {
+	Name  *string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Age   *int64  `parquet:"name=age, type=INT64, repetitiontype=OPTIONAL"`
+	Bdate *string `parquet:"name=bdate, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+func TestWriteParquetInfersColumnTypes(t *testing.T) {
+	converter := getConverter(t)
+
+	parquetFileName := "/tmp/test.parquet"
+	if err := converter.WriteParquetFile(parquetFileName); err != nil {
+		t.Fatalf("error in WriteParquetFile: %v", err)
+	}
+
+	pf, err := local.NewLocalFileReader(parquetFileName)
+	if err != nil {
+		t.Fatalf("error opening %v: %v", parquetFileName, err)
+	}
+	defer pf.Close()
+
+	pr, err := reader.NewParquetReader(pf, new(personParquetRow), 1)
+	if err != nil {
+		t.Fatalf("error creating parquet reader: %v", err)
+	}
+	defer pr.ReadStop()
+
+	rows, err := pr.ReadByNumber(int(pr.GetNumRows()))
+	if err != nil {
+		t.Fatalf("error reading parquet rows: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+
+	row := rows[0].(personParquetRow)
+	if row.Name == nil || *row.Name != "Alice" {
+		t.Errorf("expected name Alice, got %v", row.Name)
+	}
+	if row.Age == nil || *row.Age != 1 {
+		t.Errorf("expected age stored as a genuine int64(1), got %v", row.Age)
+	}
+	if row.Bdate == nil || *row.Bdate != "1973-11-29 21:33:09 +0000 UTC" {
+		t.Errorf("expected bdate %q, got %v", "1973-11-29 21:33:09 +0000 UTC", row.Bdate)
+	}
+}
+
 func checkQueryAgainstResult(t tester, innerTestFunc func(*sql.Rows) string) {
 	rows := getTestRows(t)
 
@@ -165,7 +484,7 @@ func getConverter(t *testing.T) *sqltocsv.Converter {
 func setupDatabase(t tester) *sql.DB {
 	db, err := sql.Open("test", "foo")
 	if err != nil {
-		t.Fatalf("Error opening testdb %v", err)
+		t.Fatalf("Error opening test db %v", err)
 	}
 	exec(t, db, "WIPE")
 	exec(t, db, "CREATE|people|name=string,age=int32,bdate=datetime,nickname=nullstring")
@@ -209,3 +528,33 @@ func BenchmarkWrite(b *testing.B) {
 		assertCsvMatch(b, expected, buffer.String())
 	}
 }
+
+// BenchmarkWriteConcurrent formats the same 10k-row fixture as
+// BenchmarkWrite but with a worker pool doing the formatting, to gauge
+// whether Concurrency is worth the reordering overhead for a given
+// workload.
+func BenchmarkWriteConcurrent(b *testing.B) {
+	db := setupDatabase(b)
+	// Add 10000 rows
+	expected := "name,age,bdate\nAlice,1,1973-11-29 21:33:09 +0000 UTC\n"
+	for i := 0; i < 10000; i++ {
+		exec(b, db, "INSERT|people|name=Alice,age=?,bdate=?,nickname=?", i, time.Unix(123456789, 0), nil)
+		expected += fmt.Sprintf("Alice,%d,1973-11-29 21:33:09 +0000 UTC\n", i)
+	}
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		rows, err := db.Query("SELECT|people|name,age,bdate|")
+		if err != nil {
+			b.Error(err)
+		}
+		buffer := &bytes.Buffer{}
+		converter := sqltocsv.New(rows)
+		converter.Concurrency = 8
+		err = converter.Write(buffer)
+		if err != nil {
+			b.Fatalf("error in Write: %v", err)
+		}
+		assertCsvMatch(b, expected, buffer.String())
+	}
+}